@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2022 Acorn Labs, Inc; All rights reserved.
+// Copyright 2022 Unikraft GmbH; All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/internal/cli"
+)
+
+// Config prints the effective configuration (flags > env > config file >
+// defaults) that the layered pipeline added by cli.Bind resolved for this
+// command, without reaching into config.ConfigManager directly.
+type Config struct{}
+
+func New() *cobra.Command {
+	return cli.New(&Config{}, cobra.Command{
+		Use:   "config",
+		Short: "Show the effective, merged kraftkit configuration",
+	})
+}
+
+func (opts *Config) Run(cmd *cobra.Command, args []string) error {
+	b, err := json.MarshalIndent(cli.EffectiveConfig(cmd), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), string(b))
+
+	return nil
+}
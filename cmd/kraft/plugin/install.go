@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2022 Acorn Labs, Inc; All rights reserved.
+// Copyright 2022 Unikraft GmbH; All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/internal/cli"
+)
+
+type Install struct {
+	Dir string `name:"dir" usage:"install the plugin into this directory instead of the default" env:"KRAFTKIT_PLUGIN_DIR"`
+}
+
+func NewInstall() *cobra.Command {
+	return cli.New(&Install{}, cobra.Command{
+		Use:   "install PATH",
+		Short: "Install a kraft-<name> plugin binary",
+		Args:  cobra.ExactArgs(1),
+	})
+}
+
+func (opts *Install) Run(cmd *cobra.Command, args []string) error {
+	dir := opts.Dir
+	if dir == "" {
+		dir = defaultPluginDir()
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("could not create plugin directory: %w", err)
+	}
+
+	src, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("could not open plugin binary: %w", err)
+	}
+	defer src.Close()
+
+	dst := filepath.Join(dir, filepath.Base(args[0]))
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o755)
+	if err != nil {
+		return fmt.Errorf("could not create plugin binary: %w", err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, src); err != nil {
+		return fmt.Errorf("could not install plugin binary: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "installed %s\n", dst)
+
+	return nil
+}
+
+func defaultPluginDir() string {
+	if dir := os.Getenv("KRAFTKIT_PLUGIN_DIR"); dir != "" {
+		return dir
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "kraftkit", "plugins")
+	}
+
+	return filepath.Join(home, ".local", "share", "kraftkit", "plugins")
+}
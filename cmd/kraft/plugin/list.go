@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2022 Acorn Labs, Inc; All rights reserved.
+// Copyright 2022 Unikraft GmbH; All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/internal/cli"
+	kplugin "kraftkit.sh/internal/cli/plugin"
+)
+
+type List struct{}
+
+func NewList() *cobra.Command {
+	return cli.New(&List{}, cobra.Command{
+		Use:   "list",
+		Short: "List discovered kraft plugins",
+	})
+}
+
+func (opts *List) Run(cmd *cobra.Command, args []string) error {
+	plugins := kplugin.Discover(cmd.Context())
+	if len(plugins) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "no plugins found")
+		return nil
+	}
+
+	for _, p := range plugins {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\t%s\n", p.Manifest.Name, p.Path, p.Manifest.Short)
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2022 Acorn Labs, Inc; All rights reserved.
+// Copyright 2022 Unikraft GmbH; All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+package plugin
+
+import (
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/internal/cli"
+)
+
+type Plugin struct{}
+
+func New() *cobra.Command {
+	cmd := cli.New(&Plugin{}, cobra.Command{
+		Use:   "plugin SUBCOMMAND",
+		Short: "Manage kraft plugins",
+	})
+
+	cmd.AddCommand(NewList())
+	cmd.AddCommand(NewInstall())
+	cmd.AddCommand(NewRemove())
+
+	return cmd
+}
+
+func (opts *Plugin) Run(cmd *cobra.Command, args []string) error {
+	return cmd.Help()
+}
@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2022 Acorn Labs, Inc; All rights reserved.
+// Copyright 2022 Unikraft GmbH; All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/internal/cli"
+	kplugin "kraftkit.sh/internal/cli/plugin"
+)
+
+type Remove struct{}
+
+func NewRemove() *cobra.Command {
+	return cli.New(&Remove{}, cobra.Command{
+		Use:   "remove NAME",
+		Short: "Remove an installed kraft plugin",
+		Args:  cobra.ExactArgs(1),
+	})
+}
+
+func (opts *Remove) Run(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	for _, p := range kplugin.Discover(cmd.Context()) {
+		if p.Manifest.Name != name && filepath.Base(p.Path) != "kraft-"+name {
+			continue
+		}
+
+		if err := os.Remove(p.Path); err != nil {
+			return fmt.Errorf("could not remove plugin %q: %w", name, err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "removed %s\n", p.Path)
+		return nil
+	}
+
+	return fmt.Errorf("plugin %q not found", name)
+}
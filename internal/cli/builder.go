@@ -20,6 +20,7 @@ import (
 	"github.com/spf13/pflag"
 
 	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cli/plugin"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/log"
 )
@@ -95,8 +96,9 @@ func expandRegisteredFlags(cmd *cobra.Command) {
 }
 
 func Main(cmd *cobra.Command) {
-	expandRegisteredFlags(cmd)
 	ctx := signals.SetupSignalContext()
+	plugin.RegisterCommands(cmd, plugin.Discover(ctx))
+	expandRegisteredFlags(cmd)
 	if err := cmd.ExecuteContext(ctx); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
@@ -161,6 +163,9 @@ func New(obj Runnable, cmd cobra.Command, opts ...CliOption) *cobra.Command {
 		optString = map[string]reflect.Value{}
 		optBool   = map[string]reflect.Value{}
 		optInt    = map[string]reflect.Value{}
+		required  []requiredField
+		choices   = map[string]choiceField{}
+		validates = map[string]*validateRule{}
 		ptrValue  = reflect.ValueOf(obj)
 		objValue  = ptrValue.Elem()
 	)
@@ -195,41 +200,77 @@ func New(obj Runnable, cmd cobra.Command, opts ...CliOption) *cobra.Command {
 			flags = c.LocalFlags()
 		}
 
-		switch fieldType.Type.Kind() {
-		case reflect.Int:
-			flags.IntVarP((*int)(unsafe.Pointer(v.Addr().Pointer())), name, alias, defInt, usage)
-		case reflect.Int64:
-			flags.IntVarP((*int)(unsafe.Pointer(v.Addr().Pointer())), name, alias, defInt, usage)
-		case reflect.String:
-			flags.StringVarP((*string)(unsafe.Pointer(v.Addr().Pointer())), name, alias, defValue, usage)
-		case reflect.Slice:
-			switch fieldType.Tag.Get("split") {
-			case "false":
-				arrays[name] = v
-				flags.StringArrayP(name, alias, nil, usage)
-			default:
-				slices[name] = v
-				flags.StringSliceP(name, alias, nil, usage)
-			}
-		case reflect.Map:
-			maps[name] = v
-			flags.StringSliceP(name, alias, nil, usage)
-		case reflect.Bool:
-			flags.BoolVarP((*bool)(unsafe.Pointer(v.Addr().Pointer())), name, alias, false, usage)
-		case reflect.Pointer:
-			switch fieldType.Type.Elem().Kind() {
+		handled, err := tryKind(flags, name, alias, usage, defValue, fieldType, v)
+		if err != nil {
+			panic(err)
+		}
+
+		if !handled {
+			switch fieldType.Type.Kind() {
 			case reflect.Int:
-				optInt[name] = v
-				flags.IntP(name, alias, defInt, usage)
+				flags.IntVarP((*int)(unsafe.Pointer(v.Addr().Pointer())), name, alias, defInt, usage)
+			case reflect.Int64:
+				flags.IntVarP((*int)(unsafe.Pointer(v.Addr().Pointer())), name, alias, defInt, usage)
 			case reflect.String:
-				optString[name] = v
-				flags.StringP(name, alias, defValue, usage)
+				flags.StringVarP((*string)(unsafe.Pointer(v.Addr().Pointer())), name, alias, defValue, usage)
+			case reflect.Slice:
+				switch fieldType.Tag.Get("split") {
+				case "false":
+					arrays[name] = v
+					flags.StringArrayP(name, alias, nil, usage)
+				default:
+					slices[name] = v
+					flags.StringSliceP(name, alias, nil, usage)
+				}
+			case reflect.Map:
+				maps[name] = v
+				flags.StringSliceP(name, alias, nil, usage)
 			case reflect.Bool:
-				optBool[name] = v
-				flags.BoolP(name, alias, false, usage)
+				flags.BoolVarP((*bool)(unsafe.Pointer(v.Addr().Pointer())), name, alias, false, usage)
+			case reflect.Pointer:
+				switch fieldType.Type.Elem().Kind() {
+				case reflect.Int:
+					optInt[name] = v
+					flags.IntP(name, alias, defInt, usage)
+				case reflect.String:
+					optString[name] = v
+					flags.StringP(name, alias, defValue, usage)
+				case reflect.Bool:
+					optBool[name] = v
+					flags.BoolP(name, alias, false, usage)
+				}
+			default:
+				panic("Unknown kind on field " + fieldType.Name + " on " + objValue.Type().Name())
+			}
+		}
+
+		if fieldType.Tag.Get("required") == "true" {
+			required = append(required, requiredField{name: name, hasDefault: defValue != ""})
+		}
+
+		if choicesTag := fieldType.Tag.Get("choices"); choicesTag != "" {
+			choiceValues := strings.Split(choicesTag, "|")
+			choices[name] = choiceField{allowed: choiceValues, hasDefault: defValue != ""}
+			// A `complete` tag on the same field takes precedence and
+			// registers its own completion function below.
+			if fieldType.Tag.Get("complete") == "" {
+				_ = c.RegisterFlagCompletionFunc(name, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+					return choiceValues, cobra.ShellCompDirectiveNoFileComp
+				})
 			}
-		default:
-			panic("Unknown kind on field " + fieldType.Name + " on " + objValue.Type().Name())
+		}
+
+		if validateTag := fieldType.Tag.Get("validate"); validateTag != "" {
+			rule, err := parseValidateTag(validateTag)
+			if err != nil {
+				panic(err)
+			}
+			rule.hasDefault = defValue != ""
+			validates[name] = rule
+		}
+
+		if completeTag := fieldType.Tag.Get("complete"); completeTag != "" {
+			registerFieldCompletion(&c, obj, name, completeTag)
 		}
 
 		for _, env := range env {
@@ -245,15 +286,26 @@ func New(obj Runnable, cmd cobra.Command, opts ...CliOption) *cobra.Command {
 		}
 	}
 
+	registerLogFlags(&c)
+
+	if p, ok := obj.(Completable); ok {
+		c.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return p.Complete(cmd, args, toComplete)
+		}
+	}
+
 	if p, ok := obj.(PersistentPreRunnable); ok {
 		c.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
 			cmd.SetContext(contextualize(cmd.Context(), opts...))
+			if err := configureLogFormatter(cmd); err != nil {
+				return err
+			}
 			return p.PersistentPre(cmd, args)
 		}
 	} else {
 		c.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
 			cmd.SetContext(contextualize(cmd.Context(), opts...))
-			return nil
+			return configureLogFormatter(cmd)
 		}
 	}
 
@@ -265,10 +317,14 @@ func New(obj Runnable, cmd cobra.Command, opts ...CliOption) *cobra.Command {
 	c.SilenceUsage = true
 	c.DisableFlagsInUseLine = true
 
+	if err := Bind(&c, obj); err != nil {
+		panic(err)
+	}
+
 	c.RunE = obj.Run
-	c.PersistentPreRunE = bind(c.PersistentPreRunE, arrays, slices, maps, optInt, optBool, optString, envs)
-	c.PreRunE = bind(c.PreRunE, arrays, slices, maps, optInt, optBool, optString, envs)
-	c.RunE = bind(c.RunE, arrays, slices, maps, optInt, optBool, optString, envs)
+	c.PersistentPreRunE = bind(c.PersistentPreRunE, arrays, slices, maps, optInt, optBool, optString, envs, required, choices, validates)
+	c.PreRunE = bind(c.PreRunE, arrays, slices, maps, optInt, optBool, optString, envs, required, choices, validates)
+	c.RunE = bind(c.RunE, arrays, slices, maps, optInt, optBool, optString, envs, required, choices, validates)
 
 	// Set help and usage methods
 	c.SetHelpFunc(func(cmd *cobra.Command, args []string) {
@@ -415,11 +471,18 @@ func bind(next func(*cobra.Command, []string) error,
 	optBool map[string]reflect.Value,
 	optString map[string]reflect.Value,
 	envs []func(),
+	required []requiredField,
+	choices map[string]choiceField,
+	validates map[string]*validateRule,
 ) func(*cobra.Command, []string) error {
 	if next == nil {
 		return nil
 	}
 	return func(cmd *cobra.Command, args []string) error {
+		if err := loadConfigFile(cmd); err != nil {
+			return err
+		}
+
 		for _, envCallback := range envs {
 			envCallback()
 		}
@@ -441,6 +504,9 @@ func bind(next func(*cobra.Command, []string) error,
 		if err := assignOptString(cmd, optString); err != nil {
 			return err
 		}
+		if err := validateFlags(cmd, required, choices, validates); err != nil {
+			return cmd.FlagErrorFunc()(cmd, err)
+		}
 
 		if next != nil {
 			return next(cmd, args)
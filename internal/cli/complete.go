@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2022 Acorn Labs, Inc; All rights reserved.
+// Copyright 2022 Unikraft GmbH; All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+package cli
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/internal/cli/plugin"
+)
+
+// CompleterFunc provides shell completion values for a domain-specific
+// `complete:"<kind>"` tag (e.g. "oci-ref", "unikraft-component") without the
+// core cli package having to import the packages that know how to enumerate
+// them.
+type CompleterFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+var completerRegistry = map[string]CompleterFunc{}
+
+// RegisterCompleter lets downstream packages (e.g. pkg/oci,
+// unikraft/component) contribute a completer for a `complete:"<kind>"` tag
+// without the core cli package importing them.
+func RegisterCompleter(name string, fn CompleterFunc) {
+	completerRegistry[name] = fn
+}
+
+func init() {
+	RegisterCompleter("plugin", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var names []string
+		for _, p := range plugin.Discover(cmd.Context()) {
+			names = append(names, p.Manifest.Name)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	RegisterCompleter("host", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// registerFieldCompletion wires up the `complete:"<kind>"` struct tag for a
+// single flag.  `file`/`dir` (and `file:ext1,ext2`) are handled directly via
+// Cobra's filename/dirname annotations; `func:MethodName` looks up a method
+// on obj; every other kind is resolved through the completer registry so
+// that e.g. pkg/oci and unikraft/component can contribute without being
+// imported here.
+func registerFieldCompletion(c *cobra.Command, obj Runnable, name, kind string) {
+	switch {
+	// New registers most flags on PersistentFlags, which MarkFlagFilename and
+	// MarkFlagDirname (looking only at c.Flags()) do not find.
+	case kind == "file":
+		if err := c.MarkFlagFilename(name); err != nil {
+			_ = c.MarkPersistentFlagFilename(name)
+		}
+	case strings.HasPrefix(kind, "file:"):
+		exts := strings.Split(strings.TrimPrefix(kind, "file:"), ",")
+		if err := c.MarkFlagFilename(name, exts...); err != nil {
+			_ = c.MarkPersistentFlagFilename(name, exts...)
+		}
+	case kind == "dir":
+		if err := c.MarkFlagDirname(name); err != nil {
+			_ = c.MarkPersistentFlagDirname(name)
+		}
+	case strings.HasPrefix(kind, "func:"):
+		registerMethodCompletion(c, obj, name, strings.TrimPrefix(kind, "func:"))
+	default:
+		if fn, ok := completerRegistry[kind]; ok {
+			_ = c.RegisterFlagCompletionFunc(name, cobra.CompletionFunc(fn))
+		}
+	}
+}
+
+// registerMethodCompletion wires a `complete:"func:MethodName"` tag to a
+// method on the Runnable with the signature
+// func(ctx context.Context, toComplete string) ([]string, cobra.ShellCompDirective).
+func registerMethodCompletion(c *cobra.Command, obj Runnable, name, method string) {
+	m := reflect.ValueOf(obj).MethodByName(method)
+	if !m.IsValid() {
+		return
+	}
+
+	fn, ok := m.Interface().(func(ctx context.Context, toComplete string) ([]string, cobra.ShellCompDirective))
+	if !ok {
+		return
+	}
+
+	_ = c.RegisterFlagCompletionFunc(name, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return fn(cmd.Context(), toComplete)
+	})
+}
+
+// Completable may be implemented by a Runnable to provide completion for its
+// positional arguments.  When present, New wires it up automatically as the
+// command's ValidArgsFunction.
+type Completable interface {
+	Complete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+}
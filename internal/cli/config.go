@@ -0,0 +1,356 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2022 Acorn Labs, Inc; All rights reserved.
+// Copyright 2022 Unikraft GmbH; All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+package cli
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// cfgFields remembers, per command, which fields were bound to Viper so that
+// the pre-run step inserted ahead of envCallback can write the merged
+// configuration value back into the struct. typ is the field's concrete Go
+// type rather than just its reflect.Kind, since several kinds cli.New
+// supports (time.Duration, net.IP, net.IPNet, url.URL) need dispatch by
+// type, not Kind: e.g. time.Duration and int64 both report Kind() == Int64.
+type cfgField struct {
+	key   string
+	value reflect.Value
+	typ   reflect.Type
+	flag  string
+}
+
+var cfgFieldsByCmd = map[*cobra.Command][]cfgField{}
+
+// vipersByCmd gives every command its own Viper instance.  Two unrelated
+// commands binding a same-named top-level flag (e.g. "name", "output") must
+// not resolve config/env values through each other's pflag.Flag, so the
+// process-wide viper.GetViper() singleton cannot be shared across commands.
+var vipersByCmd = map[*cobra.Command]*viper.Viper{}
+
+func viperFor(cmd *cobra.Command) *viper.Viper {
+	if v, ok := vipersByCmd[cmd]; ok {
+		return v
+	}
+	v := viper.New()
+	vipersByCmd[cmd] = v
+	return v
+}
+
+// configKey derives the dotted Viper key for a field, honouring nested
+// anonymous structs (Package.Auth.Token -> package.auth.token) and the
+// `config:"..."` tag override.
+func configKey(prefix string, fieldType reflect.StructField, flagName string) string {
+	if override := fieldType.Tag.Get("config"); override != "" {
+		return override
+	}
+	if prefix == "" {
+		return flagName
+	}
+	return prefix + "." + flagName
+}
+
+// Bind walks the same field set used by New to establish the layered
+// configuration pipeline: explicit flags take precedence over environment
+// variables, which take precedence over a config file, which takes
+// precedence over the struct's `default:` tag.
+//
+// Bind must be called after the flags for obj have already been registered
+// on cmd (New does this automatically), since it binds Viper directly to the
+// generated pflag.Flag instances.  Each cmd gets its own Viper instance, so
+// flags with the same name on unrelated commands never collide.
+func Bind(cmd *cobra.Command, obj any) error {
+	v := viperFor(cmd)
+
+	registerConfigFile(cmd, v)
+
+	var walk func(val any, prefix string) error
+	walk = func(val any, prefix string) error {
+		ptrValue := reflect.ValueOf(val)
+		objValue := ptrValue.Elem()
+
+		for i := 0; i < objValue.NumField(); i++ {
+			fieldType := objValue.Type().Field(i)
+			fieldValue := objValue.Field(i)
+
+			if fieldType.Anonymous && fieldType.Type.Kind() == reflect.Struct {
+				nestedPrefix := prefix
+				if tag := fieldType.Tag.Get("config"); tag == "-" {
+					continue
+				} else if tag != "" {
+					nestedPrefix = tag
+				} else if fieldType.Tag.Get("name") != "" {
+					nestedPrefix = joinPrefix(prefix, fieldType.Tag.Get("name"))
+				} else {
+					nestedPrefix = joinPrefix(prefix, strings.ToLower(fieldType.Name))
+				}
+				if err := walk(fieldValue.Addr().Interface(), nestedPrefix); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if fieldType.Anonymous || strings.ToUpper(fieldType.Name[0:1]) != fieldType.Name[0:1] {
+				continue
+			}
+
+			if fieldType.Tag.Get("config") == "-" {
+				continue
+			}
+
+			flagName, _ := name(fieldType.Name, fieldType.Tag.Get("name"), fieldType.Tag.Get("short"))
+			// cmd.Flag, not cmd.Flags().Lookup: New registers fields on
+			// PersistentFlags by default, which Flags() does not see until
+			// cobra merges them at parse time.
+			flag := cmd.Flag(flagName)
+			if flag == nil {
+				continue
+			}
+
+			key := configKey(prefix, fieldType, flagName)
+
+			if err := v.BindPFlag(key, flag); err != nil {
+				return err
+			}
+
+			if env := fieldType.Tag.Get("env"); env != "" {
+				envs := strings.Split(env, ",")
+				args := append([]string{key}, envs...)
+				if err := v.BindEnv(args...); err != nil {
+					return err
+				}
+			}
+
+			cfgFieldsByCmd[cmd] = append(cfgFieldsByCmd[cmd], cfgField{
+				key:   key,
+				value: fieldValue,
+				typ:   fieldType.Type,
+				flag:  flagName,
+			})
+		}
+
+		return nil
+	}
+
+	return walk(obj, "")
+}
+
+func joinPrefix(prefix, part string) string {
+	if prefix == "" {
+		return part
+	}
+	return prefix + "." + part
+}
+
+var configFileRegisteredFor = map[*cobra.Command]bool{}
+
+// registerConfigFile wires up the `--config` flag and the well-known search
+// paths for kraftkit's configuration file: $KRAFTKIT_CONFIG takes priority
+// over $XDG_CONFIG_HOME/kraftkit/config.{yaml,toml,json}.
+//
+// The guard is keyed off cmd.Root(), not a process-wide bool, for the same
+// reason as registerLogFlags: a command exercised standalone is its own
+// root and must get its own --config flag rather than being skipped because
+// an unrelated command tree registered one first.
+func registerConfigFile(cmd *cobra.Command, v *viper.Viper) {
+	root := cmd.Root()
+	if !configFileRegisteredFor[root] {
+		configFileRegisteredFor[root] = true
+		root.PersistentFlags().String("config", "", "path to a kraftkit config file")
+	}
+
+	// The config file itself is read from disk at most once per process
+	// (sharedConfigSettings), regardless of how many per-command Viper
+	// instances end up wanting it; only the merge into this command's own v
+	// happens every time.
+	_ = v.MergeConfigMap(sharedConfigSettings())
+}
+
+var (
+	sharedConfigSettingsOnce  sync.Once
+	sharedConfigSettingsCache map[string]any
+)
+
+// sharedConfigSettings resolves and reads kraftkit's configuration file
+// ($KRAFTKIT_CONFIG, or $XDG_CONFIG_HOME/kraftkit/config.{yaml,toml,json})
+// exactly once per process, returning its settings as a plain map ready to
+// be merged into any number of per-command Viper instances. A missing
+// config file is not an error: flags, env vars and struct defaults still
+// apply.
+func sharedConfigSettings() map[string]any {
+	sharedConfigSettingsOnce.Do(func() {
+		v := viper.New()
+
+		// No SetConfigType: Viper then probes every supported extension
+		// (yaml, toml, json, ...) for "config" in the search paths below.
+		v.SetConfigName("config")
+
+		if path := os.Getenv("KRAFTKIT_CONFIG"); path != "" {
+			v.SetConfigFile(path)
+		} else {
+			xdg := os.Getenv("XDG_CONFIG_HOME")
+			if xdg == "" {
+				if home, err := os.UserHomeDir(); err == nil {
+					xdg = filepath.Join(home, ".config")
+				}
+			}
+			if xdg != "" {
+				v.AddConfigPath(filepath.Join(xdg, "kraftkit"))
+			}
+		}
+
+		_ = v.ReadInConfig()
+		sharedConfigSettingsCache = v.AllSettings()
+	})
+
+	return sharedConfigSettingsCache
+}
+
+// loadConfigFile re-reads the config file named by `--config`, if the flag
+// was supplied, and then writes any Viper-resolved values back into the
+// bound struct fields using the same reflect/unsafe assignment paths used
+// elsewhere in this package.
+func loadConfigFile(cmd *cobra.Command) error {
+	v := viperFor(cmd)
+
+	if flag := cmd.Flags().Lookup("config"); flag != nil && flag.Changed {
+		v.SetConfigFile(flag.Value.String())
+		if err := v.ReadInConfig(); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range cfgFieldsByCmd[cmd] {
+		flag := cmd.Flags().Lookup(f.flag)
+		if flag != nil && flag.Changed {
+			// Explicit flags always win; nothing to do.
+			continue
+		}
+		if !v.IsSet(f.key) {
+			continue
+		}
+
+		if !applyConfigValue(v, f) {
+			// Nothing was actually written (unparsable value, or a kind
+			// this function doesn't know how to apply yet): leave Changed
+			// alone so required/choices/validate don't treat the field as
+			// having a value it never received.
+			continue
+		}
+
+		// Mark the flag Changed so downstream required/choices/validate
+		// checks in validateFlags see this field as having a resolved value,
+		// not just its kind's unset zero value.
+		if flag != nil {
+			flag.Changed = true
+		}
+	}
+
+	return nil
+}
+
+// applyConfigValue writes the Viper-resolved value for f into its bound
+// struct field, dispatching on the field's concrete type rather than its
+// reflect.Kind alone: time.Duration (Kind() == Int64) needs v.GetDuration,
+// not v.GetInt, which silently parses "30s" as 0; net.IP (Kind() == Slice,
+// since net.IP is []byte) needs net.ParseIP, not GetStringSlice, which
+// panics trying to assign a []string into it; net.IPNet and url.URL are
+// plain structs with no Kind-based case at all. It reports whether a value
+// was actually written.
+func applyConfigValue(v *viper.Viper, f cfgField) bool {
+	switch {
+	case f.typ == reflect.TypeOf(time.Duration(0)):
+		*(*time.Duration)(unsafe.Pointer(f.value.Addr().Pointer())) = v.GetDuration(f.key)
+		return true
+
+	case f.typ == reflect.TypeOf(net.IP{}):
+		ip := net.ParseIP(v.GetString(f.key))
+		if ip == nil {
+			return false
+		}
+		f.value.Set(reflect.ValueOf(ip))
+		return true
+
+	case f.typ == reflect.TypeOf(net.IPNet{}):
+		_, ipnet, err := net.ParseCIDR(v.GetString(f.key))
+		if err != nil {
+			return false
+		}
+		f.value.Set(reflect.ValueOf(*ipnet))
+		return true
+
+	case f.typ == reflect.TypeOf(url.URL{}):
+		parsed, err := url.Parse(v.GetString(f.key))
+		if err != nil {
+			return false
+		}
+		f.value.Set(reflect.ValueOf(*parsed))
+		return true
+
+	case f.typ.Kind() == reflect.Pointer:
+		switch f.typ.Elem().Kind() {
+		case reflect.String:
+			s := v.GetString(f.key)
+			f.value.Set(reflect.ValueOf(&s))
+		case reflect.Bool:
+			b := v.GetBool(f.key)
+			f.value.Set(reflect.ValueOf(&b))
+		case reflect.Int:
+			i := v.GetInt(f.key)
+			f.value.Set(reflect.ValueOf(&i))
+		default:
+			return false
+		}
+		return true
+
+	case f.typ.Kind() == reflect.String:
+		*(*string)(unsafe.Pointer(f.value.Addr().Pointer())) = v.GetString(f.key)
+		return true
+
+	case f.typ.Kind() == reflect.Int, f.typ.Kind() == reflect.Int64:
+		*(*int)(unsafe.Pointer(f.value.Addr().Pointer())) = v.GetInt(f.key)
+		return true
+
+	case f.typ.Kind() == reflect.Bool:
+		*(*bool)(unsafe.Pointer(f.value.Addr().Pointer())) = v.GetBool(f.key)
+		return true
+
+	case f.typ.Kind() == reflect.Slice && f.typ.Elem().Kind() == reflect.String:
+		f.value.Set(reflect.ValueOf(v.GetStringSlice(f.key)))
+		return true
+
+	case f.typ.Kind() == reflect.Map:
+		values := map[string]string{}
+		for k, val := range v.GetStringMapString(f.key) {
+			values[k] = val
+		}
+		f.value.Set(reflect.ValueOf(values))
+		return true
+
+	default:
+		return false
+	}
+}
+
+// EffectiveConfig returns the fully merged configuration (flags > env >
+// config file > defaults) for cmd, as seen by its Viper instance, as a nested
+// map: each dotted config key produced by Bind is split at its dots, so
+// package.auth.token appears under "package" -> "auth" -> "token".  It is intended for commands such
+// as `kraft config` that need to print the effective view without reaching
+// into config.ConfigManager directly.
+func EffectiveConfig(cmd *cobra.Command) map[string]any {
+	return viperFor(cmd).AllSettings()
+}
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2022 Acorn Labs, Inc; All rights reserved.
+// Copyright 2022 Unikraft GmbH; All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+package cli
+
+import (
+	"net"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// cfgFieldFor builds a cfgField pointing at addr's pointed-to value, the same
+// shape Bind produces for a bound struct field.
+func cfgFieldFor(key string, addr any) cfgField {
+	value := reflect.ValueOf(addr).Elem()
+	return cfgField{key: key, value: value, typ: value.Type(), flag: key}
+}
+
+func TestApplyConfigValueDuration(t *testing.T) {
+	v := viper.New()
+	v.Set("timeout", "30s")
+
+	var d time.Duration
+	if !applyConfigValue(v, cfgFieldFor("timeout", &d)) {
+		t.Fatalf("applyConfigValue reported no value written")
+	}
+	if d != 30*time.Second {
+		t.Fatalf("got %v, want 30s", d)
+	}
+}
+
+func TestApplyConfigValueIP(t *testing.T) {
+	v := viper.New()
+	v.Set("addr", "192.168.1.1")
+
+	var ip net.IP
+	if !applyConfigValue(v, cfgFieldFor("addr", &ip)) {
+		t.Fatalf("applyConfigValue reported no value written")
+	}
+	if !ip.Equal(net.ParseIP("192.168.1.1")) {
+		t.Fatalf("got %v, want 192.168.1.1", ip)
+	}
+}
+
+func TestApplyConfigValueIPInvalid(t *testing.T) {
+	v := viper.New()
+	v.Set("addr", "not-an-ip")
+
+	var ip net.IP
+	if applyConfigValue(v, cfgFieldFor("addr", &ip)) {
+		t.Fatalf("applyConfigValue reported a value written for an unparsable IP")
+	}
+	if ip != nil {
+		t.Fatalf("got %v, want field left untouched", ip)
+	}
+}
+
+func TestApplyConfigValueURL(t *testing.T) {
+	v := viper.New()
+	v.Set("endpoint", "https://example.com/path")
+
+	var u url.URL
+	if !applyConfigValue(v, cfgFieldFor("endpoint", &u)) {
+		t.Fatalf("applyConfigValue reported no value written")
+	}
+	if u.String() != "https://example.com/path" {
+		t.Fatalf("got %v, want https://example.com/path", u.String())
+	}
+}
+
+func TestApplyConfigValueStringPointer(t *testing.T) {
+	v := viper.New()
+	v.Set("name", "plugin")
+
+	var s *string
+	if !applyConfigValue(v, cfgFieldFor("name", &s)) {
+		t.Fatalf("applyConfigValue reported no value written")
+	}
+	if s == nil || *s != "plugin" {
+		t.Fatalf("got %v, want *string(plugin)", s)
+	}
+}
@@ -0,0 +1,323 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2022 Acorn Labs, Inc; All rights reserved.
+// Copyright 2022 Unikraft GmbH; All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+package cli
+
+import (
+	"encoding"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// KindHandler binds a struct field of a type cli.New does not natively
+// understand to flags, mirroring the same metadata (name, shorthand, usage,
+// default) that the native switch in New already extracts from struct tags.
+type KindHandler func(flags *pflag.FlagSet, name, alias, usage, defValue string, v reflect.Value) error
+
+var kindRegistry = map[reflect.Type]KindHandler{}
+
+// RegisterKind lets downstream packages (e.g. unikraft/component) plug their
+// own types into cli.New's reflection loop without the core cli package
+// having to import them.
+func RegisterKind(kind reflect.Type, handler KindHandler) {
+	kindRegistry[kind] = handler
+}
+
+func init() {
+	RegisterKind(reflect.TypeOf(time.Duration(0)), func(flags *pflag.FlagSet, name, alias, usage, defValue string, v reflect.Value) error {
+		d, _ := time.ParseDuration(defValue)
+		flags.DurationVarP(v.Addr().Interface().(*time.Duration), name, alias, d, usage)
+		return nil
+	})
+
+	RegisterKind(reflect.TypeOf(net.IP{}), func(flags *pflag.FlagSet, name, alias, usage, defValue string, v reflect.Value) error {
+		flags.IPVarP(v.Addr().Interface().(*net.IP), name, alias, net.ParseIP(defValue), usage)
+		return nil
+	})
+
+	RegisterKind(reflect.TypeOf(net.IPNet{}), func(flags *pflag.FlagSet, name, alias, usage, defValue string, v reflect.Value) error {
+		var def net.IPNet
+		if defValue != "" {
+			if _, ipnet, err := net.ParseCIDR(defValue); err == nil {
+				def = *ipnet
+			}
+		}
+		flags.IPNetVarP(v.Addr().Interface().(*net.IPNet), name, alias, def, usage)
+		return nil
+	})
+
+	RegisterKind(reflect.TypeOf(url.URL{}), func(flags *pflag.FlagSet, name, alias, usage, defValue string, v reflect.Value) error {
+		flags.VarP(newURLValue(defValue, v.Addr().Interface().(*url.URL)), name, alias, usage)
+		return nil
+	})
+}
+
+// tryKind resolves a field via the kind registry first and, failing that,
+// via a generic encoding.TextUnmarshaler adapter, so that any type capable
+// of parsing itself from a string can be used as a flag without cli needing
+// to know about it in advance.
+func tryKind(flags *pflag.FlagSet, name, alias, usage, defValue string, fieldType reflect.StructField, v reflect.Value) (bool, error) {
+	if handler, ok := kindRegistry[fieldType.Type]; ok {
+		return true, handler(flags, name, alias, usage, defValue, v)
+	}
+
+	addr := v.Addr()
+	if u, ok := addr.Interface().(encoding.TextUnmarshaler); ok {
+		if defValue != "" {
+			if err := u.UnmarshalText([]byte(defValue)); err != nil {
+				return true, err
+			}
+		}
+		m, _ := addr.Interface().(encoding.TextMarshaler)
+		flags.VarP(&textValue{u: u, m: m}, name, alias, usage)
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// textValue adapts any encoding.TextUnmarshaler/TextMarshaler pair to
+// pflag.Value so it can be registered as a flag.
+type textValue struct {
+	u encoding.TextUnmarshaler
+	m encoding.TextMarshaler
+}
+
+func (t *textValue) String() string {
+	if t.m == nil {
+		return ""
+	}
+	b, err := t.m.MarshalText()
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func (t *textValue) Set(s string) error {
+	return t.u.UnmarshalText([]byte(s))
+}
+
+func (t *textValue) Type() string {
+	return "value"
+}
+
+// urlValue adapts *url.URL to pflag.Value, since pflag has no native support
+// for it.
+type urlValue struct {
+	u *url.URL
+}
+
+func newURLValue(defValue string, u *url.URL) *urlValue {
+	if defValue != "" {
+		if parsed, err := url.Parse(defValue); err == nil {
+			*u = *parsed
+		}
+	}
+	return &urlValue{u: u}
+}
+
+func (v *urlValue) String() string {
+	if v.u == nil {
+		return ""
+	}
+	return v.u.String()
+}
+
+func (v *urlValue) Set(s string) error {
+	parsed, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	*v.u = *parsed
+	return nil
+}
+
+func (v *urlValue) Type() string {
+	return "url"
+}
+
+// ErrMissingFlag is returned when one or more flags tagged `required:"true"`
+// were not set by any layer of the configuration pipeline (flag, env, config
+// file or default).  All missing flags are reported together rather than
+// failing on the first one found.
+type ErrMissingFlag struct {
+	Flags []string
+}
+
+func (e *ErrMissingFlag) Error() string {
+	return fmt.Sprintf("required flag(s) %q not set", strings.Join(e.Flags, ", "))
+}
+
+// validateRule is the parsed form of the `validate:"..."` struct tag, e.g.
+// `validate:"regex=^[a-z]+$;min=1;max=10;oneof=a|b|c"`.
+type validateRule struct {
+	regex      *regexp.Regexp
+	min        *float64
+	max        *float64
+	oneof      []string
+	hasDefault bool
+}
+
+func parseValidateTag(tag string) (*validateRule, error) {
+	rule := &validateRule{}
+
+	for _, part := range strings.Split(tag, ";") {
+		if part == "" {
+			continue
+		}
+
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid validate rule %q", part)
+		}
+
+		switch kv[0] {
+		case "regex":
+			re, err := regexp.Compile(kv[1])
+			if err != nil {
+				return nil, err
+			}
+			rule.regex = re
+		case "min":
+			f, err := strconv.ParseFloat(kv[1], 64)
+			if err != nil {
+				return nil, err
+			}
+			rule.min = &f
+		case "max":
+			f, err := strconv.ParseFloat(kv[1], 64)
+			if err != nil {
+				return nil, err
+			}
+			rule.max = &f
+		case "oneof":
+			rule.oneof = strings.Split(kv[1], "|")
+		default:
+			return nil, fmt.Errorf("unknown validate rule %q", kv[0])
+		}
+	}
+
+	return rule, nil
+}
+
+func (r *validateRule) check(name, value string) error {
+	if r.regex != nil && !r.regex.MatchString(value) {
+		return fmt.Errorf("--%s: value %q does not match pattern %q", name, value, r.regex.String())
+	}
+
+	if len(r.oneof) > 0 {
+		ok := false
+		for _, choice := range r.oneof {
+			if choice == value {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return fmt.Errorf("--%s: value %q must be one of %s", name, value, strings.Join(r.oneof, ", "))
+		}
+	}
+
+	if r.min != nil || r.max != nil {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("--%s: value %q is not numeric", name, value)
+		}
+		if r.min != nil && f < *r.min {
+			return fmt.Errorf("--%s: value %v is below the minimum of %v", name, f, *r.min)
+		}
+		if r.max != nil && f > *r.max {
+			return fmt.Errorf("--%s: value %v is above the maximum of %v", name, f, *r.max)
+		}
+	}
+
+	return nil
+}
+
+// requiredField is the parsed form of a `required:"true"` struct tag.
+// hasDefault records whether the field also carried a non-empty `default`
+// tag, since a default already satisfies "required" even when the flag was
+// never explicitly changed.
+type requiredField struct {
+	name       string
+	hasDefault bool
+}
+
+// choiceField is the parsed form of a `choices:"a|b|c"` struct tag.
+type choiceField struct {
+	allowed    []string
+	hasDefault bool
+}
+
+// flagHasValue reports whether flag was ever given a value by some layer of
+// the configuration pipeline (CLI flag, env var or config file all mark
+// Changed; see envCallback and loadConfigFile) or carries a non-empty
+// default, as opposed to sitting at its kind's unset zero value. This is
+// deliberately not flag.Value.String() == "": kinds such as time.Duration,
+// net.IP and the numeric kinds never stringify their zero value to "", so a
+// string-emptiness check silently never reports them missing, and
+// conversely never skips validating them when untouched.
+func flagHasValue(flag *pflag.Flag, hasDefault bool) bool {
+	return flag.Changed || hasDefault
+}
+
+// validateFlags enforces `required`, `choices` and `validate` struct tags
+// once every layer of the configuration pipeline (flag, env, config file,
+// default) has already been resolved into the underlying flags.
+func validateFlags(cmd *cobra.Command, required []requiredField, choices map[string]choiceField, validates map[string]*validateRule) error {
+	var missing []string
+	for _, rf := range required {
+		flag := cmd.Flags().Lookup(rf.name)
+		if flag == nil {
+			continue
+		}
+		if !flagHasValue(flag, rf.hasDefault) {
+			missing = append(missing, rf.name)
+		}
+	}
+	if len(missing) > 0 {
+		return &ErrMissingFlag{Flags: missing}
+	}
+
+	for name, cf := range choices {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || !flagHasValue(flag, cf.hasDefault) {
+			continue
+		}
+		value := flag.Value.String()
+		ok := false
+		for _, choice := range cf.allowed {
+			if choice == value {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return fmt.Errorf("--%s: value %q must be one of %s", name, value, strings.Join(cf.allowed, ", "))
+		}
+	}
+
+	for name, rule := range validates {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || !flagHasValue(flag, rule.hasDefault) {
+			continue
+		}
+		if err := rule.check(name, flag.Value.String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
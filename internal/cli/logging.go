@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2022 Acorn Labs, Inc; All rights reserved.
+// Copyright 2022 Unikraft GmbH; All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+package cli
+
+import (
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+)
+
+var logFlagsRegisteredFor = map[*cobra.Command]bool{}
+
+// registerLogFlags adds the persistent --log-type, --log-timestamps and
+// --log-caller flags once per command tree.  The guard is keyed off
+// cmd.Root() rather than a single process-wide bool: a command exercised
+// standalone (e.g. calling cmd.Execute() directly on a leaf without
+// attaching it under its parent, the common cobra test pattern) is its own
+// root at this point and must get its own copy of these flags, not be
+// skipped because some unrelated command tree registered them first.
+func registerLogFlags(cmd *cobra.Command) {
+	root := cmd.Root()
+	if logFlagsRegisteredFor[root] {
+		return
+	}
+	logFlagsRegisteredFor[root] = true
+
+	root.PersistentFlags().String("log-type", "text", "log formatter to use (text|json|logfmt|none)")
+	root.PersistentFlags().String("log-timestamps", "full", "timestamp format to use in log output (none|full|relative|rfc3339)")
+	root.PersistentFlags().Bool("log-caller", false, "include the caller's file and line in log output")
+}
+
+// configureLogFormatter builds a log.Formatter from the resolved
+// --log-type/--log-timestamps/--log-caller flags and installs it on the
+// logger already placed in cmd's context by contextualize.
+func configureLogFormatter(cmd *cobra.Command) error {
+	logType, err := cmd.Flags().GetString("log-type")
+	if err != nil {
+		return err
+	}
+	timestamps, err := cmd.Flags().GetString("log-timestamps")
+	if err != nil {
+		return err
+	}
+	caller, err := cmd.Flags().GetBool("log-caller")
+	if err != nil {
+		return err
+	}
+
+	formatter, err := log.NewFormatter(logType, iostreams.FromContext(cmd.Context()), log.TimestampFormat(timestamps), caller)
+	if err != nil {
+		return err
+	}
+
+	if logger := log.FromContext(cmd.Context()); logger != nil {
+		logger.SetFormatter(formatter)
+	}
+
+	return nil
+}
@@ -0,0 +1,236 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2022 Acorn Labs, Inc; All rights reserved.
+// Copyright 2022 Unikraft GmbH; All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"kraftkit.sh/iostreams"
+)
+
+// RegisterCommands attaches a cobra.Command for every discovered plugin to
+// root.  Nesting comes from two sources: a plugin's own filename
+// (kraft-<topic>-<sub> nests "sub" under "topic"), keyed by its dash-joined
+// path, and its self-reported manifest.Subcommands, which are attached as
+// new children of that plugin's command and are not merged with nodes
+// derived from other binaries' filenames.  A kraft-<topic> and a
+// kraft-<topic>-<sub> binary discovered in either order share one "topic"
+// command node rather than producing two.
+func RegisterCommands(root *cobra.Command, plugins []*Plugin) {
+	topics := map[string]*cobra.Command{}
+
+	for _, p := range plugins {
+		segments := pluginNameSegments(p.Path)
+		if len(segments) == 0 {
+			continue
+		}
+
+		parent := root
+		key := ""
+		for _, seg := range segments[:len(segments)-1] {
+			key = joinTopicKey(key, seg)
+			parent = ensureTopicCommand(topics, parent, key, seg)
+		}
+
+		leafKey := joinTopicKey(key, segments[len(segments)-1])
+		leaf, ok := topics[leafKey]
+		if !ok {
+			leaf = &cobra.Command{Use: segments[len(segments)-1]}
+			parent.AddCommand(leaf)
+			topics[leafKey] = leaf
+		}
+
+		applyPluginManifest(leaf, p.Path, &p.Manifest)
+	}
+}
+
+// pluginNameSegments splits a kraft-<topic>[-<sub>...] binary's basename
+// into its command path, e.g. "kraft-foo-bar" -> ["foo", "bar"].
+func pluginNameSegments(path string) []string {
+	base := filepath.Base(path)
+	name := strings.TrimPrefix(base, "kraft-")
+	if name == base || name == "" {
+		return nil
+	}
+	return strings.Split(name, "-")
+}
+
+func joinTopicKey(prefix, seg string) string {
+	if prefix == "" {
+		return seg
+	}
+	return prefix + "-" + seg
+}
+
+// ensureTopicCommand returns the command already registered for key, or
+// creates and attaches a bare placeholder for it.  The placeholder gets its
+// Short/Long/Flags/RunE filled in later if a kraft-<topic> binary for this
+// exact key is also discovered.
+func ensureTopicCommand(topics map[string]*cobra.Command, parent *cobra.Command, key, use string) *cobra.Command {
+	if cmd, ok := topics[key]; ok {
+		return cmd
+	}
+	cmd := &cobra.Command{Use: use}
+	parent.AddCommand(cmd)
+	topics[key] = cmd
+	return cmd
+}
+
+func newPluginCommand(path string, manifest *Manifest) *cobra.Command {
+	cmd := &cobra.Command{Use: manifest.Name}
+	applyPluginManifest(cmd, path, manifest)
+	return cmd
+}
+
+// applyPluginManifest installs manifest's own metadata, flags and (via
+// manifest.Subcommands) any further nesting it self-reports onto cmd, which
+// may already exist as a placeholder created for a sibling
+// kraft-<topic>-<sub> binary discovered first.
+func applyPluginManifest(cmd *cobra.Command, path string, manifest *Manifest) {
+	cmd.Short = manifest.Short
+	cmd.Long = manifest.Long
+
+	for _, flag := range manifest.Flags {
+		addPluginFlag(cmd, flag)
+	}
+
+	if len(manifest.Subcommands) > 0 {
+		for _, sub := range manifest.Subcommands {
+			cmd.AddCommand(newPluginCommand(path, sub))
+		}
+	} else {
+		cmd.RunE = func(cmd *cobra.Command, args []string) error {
+			if err := resolvePluginFlags(cmd, manifest.Flags); err != nil {
+				return cmd.FlagErrorFunc()(cmd, err)
+			}
+			return execPlugin(cmd.Context(), path, cmd, args)
+		}
+	}
+}
+
+// resolvePluginFlags applies each flag's env fallback (mirroring cli.New's
+// own env handling: only when the flag was not explicitly set on the
+// command line) and then reports any `required` flag that is still unset
+// once flags and env have both had a chance to supply it. A non-empty
+// Default already satisfies `required`, the same as cli.New's own
+// flagHasValue check in kind.go.
+func resolvePluginFlags(cmd *cobra.Command, schemas []FlagSchema) error {
+	var missing []string
+
+	for _, schema := range schemas {
+		flag := cmd.Flags().Lookup(schema.Name)
+		if flag == nil {
+			continue
+		}
+
+		if !flag.Changed {
+			for _, env := range schema.Env {
+				if v := os.Getenv(env); v != "" {
+					if err := flag.Value.Set(v); err == nil {
+						flag.Changed = true
+					}
+					break
+				}
+			}
+		}
+
+		if schema.Required && !flag.Changed && schema.Default == "" {
+			missing = append(missing, schema.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("required flag(s) %q not set", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+func addPluginFlag(cmd *cobra.Command, schema FlagSchema) {
+	switch schema.Kind {
+	case "bool":
+		def, _ := strconv.ParseBool(schema.Default)
+		cmd.Flags().BoolP(schema.Name, schema.Shorthand, def, schema.Usage)
+	case "int":
+		def, _ := strconv.Atoi(schema.Default)
+		cmd.Flags().IntP(schema.Name, schema.Shorthand, def, schema.Usage)
+	case "stringSlice":
+		cmd.Flags().StringSliceP(schema.Name, schema.Shorthand, nil, schema.Usage)
+	default:
+		cmd.Flags().StringP(schema.Name, schema.Shorthand, schema.Default, schema.Usage)
+	}
+
+	if len(schema.Choices) > 0 {
+		choices := schema.Choices
+		_ = cmd.RegisterFlagCompletionFunc(schema.Name, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return choices, cobra.ShellCompDirectiveNoFileComp
+		})
+	}
+}
+
+// execPlugin reconstructs argv from whichever flags were actually set on
+// cmd, appends the remaining positional args, and execs the plugin binary
+// with the context's IOStreams wired up as stdio.
+func execPlugin(ctx context.Context, path string, cmd *cobra.Command, args []string) error {
+	var argv []string
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		switch f.Value.Type() {
+		case "bool":
+			argv = append(argv, "--"+f.Name+"="+f.Value.String())
+		case "stringSlice":
+			// f.Value.String() renders a stringSlice as a bracketed CSV
+			// ("[a,b,c]"), which doesn't round-trip through a plugin's own
+			// flag parser; emit one repeated --name value per element
+			// instead, pflag's own convention for repeatable flags.
+			values, _ := cmd.Flags().GetStringSlice(f.Name)
+			for _, value := range values {
+				argv = append(argv, "--"+f.Name, value)
+			}
+		default:
+			argv = append(argv, "--"+f.Name, f.Value.String())
+		}
+	})
+	argv = append(argv, args...)
+
+	c := exec.CommandContext(ctx, path, argv...)
+	c.Env = pluginEnv()
+
+	if streams := iostreams.FromContext(ctx); streams != nil {
+		c.Stdin = streams.In
+		c.Stdout = streams.Out
+		c.Stderr = streams.ErrOut
+	} else {
+		c.Stdin = os.Stdin
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+	}
+
+	return c.Run()
+}
+
+// pluginEnv forwards only the environment a plugin needs to resolve its own
+// configuration and logging the same way the parent process would.
+func pluginEnv() []string {
+	var env []string
+	for _, e := range os.Environ() {
+		if strings.HasPrefix(e, "KRAFTKIT_") ||
+			strings.HasPrefix(e, "XDG_CONFIG_HOME=") ||
+			strings.HasPrefix(e, "PATH=") ||
+			strings.HasPrefix(e, "HOME=") {
+			env = append(env, e)
+		}
+	}
+	return env
+}
@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2022 Acorn Labs, Inc; All rights reserved.
+// Copyright 2022 Unikraft GmbH; All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"kraftkit.sh/log"
+)
+
+// metadataFlag is passed to a candidate binary to ask it to describe itself.
+const metadataFlag = "--kraft-plugin-metadata"
+
+// metadataTimeout bounds a single --kraft-plugin-metadata probe so that a
+// binary which isn't actually a kraftkit plugin (or hangs waiting on stdin)
+// can only ever stall its own entry in Discover, not every kraft invocation.
+const metadataTimeout = 2 * time.Second
+
+// Plugin is a discovered kraft-<name> binary along with the manifest it
+// reported for --kraft-plugin-metadata.
+type Plugin struct {
+	Path     string
+	Manifest Manifest
+}
+
+// Discover scans $PATH and $KRAFTKIT_PLUGIN_DIR for executables named
+// kraft-<topic> (or kraft-<topic>-<sub> for nested subcommands) and asks
+// each for its manifest.  A plugin that exits non-zero or writes invalid
+// JSON to stdout is skipped with a debug log rather than failing discovery
+// for every other plugin.
+func Discover(ctx context.Context) []*Plugin {
+	var plugins []*Plugin
+	seen := map[string]bool{}
+
+	for _, dir := range searchDirs() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasPrefix(entry.Name(), "kraft-") {
+				continue
+			}
+
+			path := filepath.Join(dir, entry.Name())
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+
+			manifest, err := fetchManifest(ctx, path)
+			if err != nil {
+				log.G(ctx).Debugf("skipping plugin %s: %v", path, err)
+				continue
+			}
+
+			plugins = append(plugins, &Plugin{Path: path, Manifest: *manifest})
+		}
+	}
+
+	return plugins
+}
+
+func searchDirs() []string {
+	var dirs []string
+
+	if path := os.Getenv("PATH"); path != "" {
+		dirs = append(dirs, filepath.SplitList(path)...)
+	}
+
+	if pluginDir := os.Getenv("KRAFTKIT_PLUGIN_DIR"); pluginDir != "" {
+		dirs = append(dirs, pluginDir)
+	}
+
+	return dirs
+}
+
+func fetchManifest(ctx context.Context, path string) (*Manifest, error) {
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path, metadataFlag)
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = nil
+
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(stdout.Bytes(), &manifest); err != nil {
+		return nil, err
+	}
+
+	return &manifest, nil
+}
@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2022 Acorn Labs, Inc; All rights reserved.
+// Copyright 2022 Unikraft GmbH; All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+package plugin
+
+// FlagSchema describes a single flag a plugin wants registered against its
+// cobra.Command, using the same vocabulary as the struct tags cli.New reads
+// off a Runnable (name, short, usage, default, env, required, choices), with
+// the `short` tag carried here as Shorthand.
+type FlagSchema struct {
+	Name      string   `json:"name"`
+	Shorthand string   `json:"shorthand,omitempty"`
+	Usage     string   `json:"usage,omitempty"`
+	Kind      string   `json:"kind"`
+	Default   string   `json:"default,omitempty"`
+	Env       []string `json:"env,omitempty"`
+	Required  bool     `json:"required,omitempty"`
+	Choices   []string `json:"choices,omitempty"`
+}
+
+// Manifest is the JSON document a `kraft-<name>` binary must print to stdout
+// in response to being invoked with `--kraft-plugin-metadata`.
+type Manifest struct {
+	Name        string       `json:"name"`
+	Short       string       `json:"short"`
+	Long        string       `json:"long,omitempty"`
+	Flags       []FlagSchema `json:"flags,omitempty"`
+	Subcommands []*Manifest  `json:"subcommands,omitempty"`
+}
@@ -0,0 +1,323 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2022 Acorn Labs, Inc; All rights reserved.
+// Copyright 2022 Unikraft GmbH; All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"kraftkit.sh/iostreams"
+)
+
+// Level is the severity of a log Entry.
+type Level string
+
+const (
+	DebugLevel Level = "debug"
+	InfoLevel  Level = "info"
+	WarnLevel  Level = "warn"
+	ErrorLevel Level = "error"
+	FatalLevel Level = "fatal"
+)
+
+// Entry is a single log record handed to a Formatter.
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Message string
+	Caller  string
+	Fields  map[string]any
+}
+
+// Formatter renders an Entry to bytes.  It is analogous to a logrus
+// formatter, except bound to kraftkit's own logger rather than logrus.
+type Formatter interface {
+	Format(entry *Entry) ([]byte, error)
+}
+
+// TimestampFormat controls how a Formatter renders an Entry's Time, selected
+// via the --log-timestamps flag.
+type TimestampFormat string
+
+const (
+	TimestampNone     TimestampFormat = "none"
+	TimestampFull     TimestampFormat = "full"
+	TimestampRelative TimestampFormat = "relative"
+	TimestampRFC3339  TimestampFormat = "rfc3339"
+)
+
+// NewFormatter constructs the Formatter selected by the --log-type flag.
+func NewFormatter(logType string, ioStreams *iostreams.IOStreams, timestamps TimestampFormat, caller bool) (Formatter, error) {
+	switch logType {
+	case "", "text":
+		return &TextFormatter{IOStreams: ioStreams, Timestamps: timestamps, Caller: caller, start: time.Now()}, nil
+	case "json":
+		return &JSONFormatter{}, nil
+	case "logfmt":
+		return &LogfmtFormatter{Timestamps: timestamps}, nil
+	case "none":
+		return &NoneFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("unknown log formatter %q", logType)
+	}
+}
+
+// TextFormatter renders a human-readable, optionally colorized line per
+// Entry: [timestamp] LEVEL  [caller] msg="..." field=value ...
+type TextFormatter struct {
+	IOStreams  *iostreams.IOStreams
+	Timestamps TimestampFormat
+	Caller     bool
+
+	start time.Time
+}
+
+var textLevelColors = map[Level]int{
+	DebugLevel: 37,
+	InfoLevel:  36,
+	WarnLevel:  33,
+	ErrorLevel: 31,
+	FatalLevel: 31,
+}
+
+func (f *TextFormatter) Format(entry *Entry) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch f.Timestamps {
+	case TimestampFull:
+		buf.WriteString(entry.Time.Format("2006-01-02T15:04:05.000Z07:00"))
+		buf.WriteByte(' ')
+	case TimestampRFC3339:
+		buf.WriteString(entry.Time.Format(time.RFC3339))
+		buf.WriteByte(' ')
+	case TimestampRelative:
+		fmt.Fprintf(&buf, "+%s ", entry.Time.Sub(f.start).Round(time.Millisecond))
+	case TimestampNone, "":
+	}
+
+	level := strings.ToUpper(string(entry.Level))
+	if pad := 7 - len(level); pad > 0 {
+		level += strings.Repeat(" ", pad)
+	}
+	if f.IOStreams != nil && f.IOStreams.ColorEnabled() {
+		if color, ok := textLevelColors[entry.Level]; ok {
+			level = fmt.Sprintf("\x1b[%dm%s\x1b[0m", color, level)
+		}
+	}
+	buf.WriteString(level)
+	buf.WriteByte(' ')
+
+	if f.Caller && entry.Caller != "" {
+		buf.WriteString(entry.Caller)
+		buf.WriteByte(' ')
+	}
+
+	buf.WriteString("msg=")
+	buf.WriteString(strconv.Quote(entry.Message))
+
+	for _, k := range sortedFieldKeys(entry.Fields) {
+		fmt.Fprintf(&buf, " %s=%v", k, entry.Fields[k])
+	}
+
+	buf.WriteByte('\n')
+
+	return buf.Bytes(), nil
+}
+
+// JSONFormatter renders each Entry as a single line of structured JSON.
+// Field order is whatever encoding/json already guarantees for map keys:
+// alphabetical.
+type JSONFormatter struct{}
+
+func (f *JSONFormatter) Format(entry *Entry) ([]byte, error) {
+	m := make(map[string]any, len(entry.Fields)+3)
+	for k, v := range entry.Fields {
+		m[k] = v
+	}
+	m["time"] = entry.Time.Format(time.RFC3339Nano)
+	m["level"] = string(entry.Level)
+	m["msg"] = entry.Message
+	if entry.Caller != "" {
+		m["caller"] = entry.Caller
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(b, '\n'), nil
+}
+
+// LogfmtFormatter renders each Entry as a logfmt (key=value) line.
+type LogfmtFormatter struct {
+	Timestamps TimestampFormat
+}
+
+func (f *LogfmtFormatter) Format(entry *Entry) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch f.Timestamps {
+	case TimestampNone:
+	case TimestampRFC3339:
+		fmt.Fprintf(&buf, "ts=%s ", entry.Time.Format(time.RFC3339))
+	default:
+		fmt.Fprintf(&buf, "ts=%s ", entry.Time.Format(time.RFC3339Nano))
+	}
+
+	fmt.Fprintf(&buf, "level=%s msg=%s", entry.Level, logfmtQuote(entry.Message))
+
+	for _, k := range sortedFieldKeys(entry.Fields) {
+		fmt.Fprintf(&buf, " %s=%s", k, logfmtQuote(fmt.Sprint(entry.Fields[k])))
+	}
+
+	buf.WriteByte('\n')
+
+	return buf.Bytes(), nil
+}
+
+func logfmtQuote(s string) string {
+	if s == "" || strings.ContainsAny(s, " =\"") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// NoneFormatter renders only the message, discarding level, timestamp,
+// caller and fields.
+type NoneFormatter struct{}
+
+func (f *NoneFormatter) Format(entry *Entry) ([]byte, error) {
+	return []byte(entry.Message + "\n"), nil
+}
+
+func sortedFieldKeys(fields map[string]any) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Logger is the package's single log sink: every Debugf/Infof/Warnf/Errorf
+// call builds an Entry and renders it through whichever Formatter is
+// currently installed, so switching --log-type at runtime (see
+// internal/cli's configureLogFormatter) changes real CLI output rather than
+// just exercising the Formatter types above in isolation.
+type Logger struct {
+	mu        sync.Mutex
+	out       io.Writer
+	formatter Formatter
+	level     Level
+}
+
+// NewLogger returns a Logger that writes to out using a TextFormatter and
+// InfoLevel until SetFormatter/SetLevel are called.
+func NewLogger(out io.Writer) *Logger {
+	return &Logger{
+		out:       out,
+		formatter: &TextFormatter{},
+		level:     InfoLevel,
+	}
+}
+
+// SetFormatter installs the Formatter every subsequent log call is rendered
+// through.
+func (l *Logger) SetFormatter(formatter Formatter) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.formatter = formatter
+}
+
+// SetLevel sets the minimum Level that is actually rendered; calls below it
+// are silently dropped.
+func (l *Logger) SetLevel(level Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = level
+}
+
+func (l *Logger) Debugf(format string, args ...any) { l.logf(DebugLevel, format, args...) }
+func (l *Logger) Infof(format string, args ...any)  { l.logf(InfoLevel, format, args...) }
+func (l *Logger) Warnf(format string, args ...any)  { l.logf(WarnLevel, format, args...) }
+func (l *Logger) Errorf(format string, args ...any) { l.logf(ErrorLevel, format, args...) }
+
+var levelSeverity = map[Level]int{
+	DebugLevel: 0,
+	InfoLevel:  1,
+	WarnLevel:  2,
+	ErrorLevel: 3,
+	FatalLevel: 4,
+}
+
+func (l *Logger) logf(level Level, format string, args ...any) {
+	l.mu.Lock()
+	formatter, out, minLevel := l.formatter, l.out, l.level
+	l.mu.Unlock()
+
+	if out == nil || formatter == nil || levelSeverity[level] < levelSeverity[minLevel] {
+		return
+	}
+
+	b, err := formatter.Format(&Entry{
+		Time:    time.Now(),
+		Level:   level,
+		Message: fmt.Sprintf(format, args...),
+		Caller:  caller(),
+	})
+	if err != nil {
+		return
+	}
+
+	_, _ = out.Write(b)
+}
+
+// caller returns "file:line" for whoever called the exported Debugf/Infof/
+// Warnf/Errorf method, so --log-caller reports the real call site rather
+// than logf itself.
+func caller() string {
+	_, file, line, ok := runtime.Caller(3)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", file, line)
+}
+
+type ctxKey struct{}
+
+// WithLogger attaches logger to ctx, retrievable via FromContext/G.
+func WithLogger(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, logger)
+}
+
+// FromContext returns the Logger attached to ctx, or nil if none was set.
+func FromContext(ctx context.Context) *Logger {
+	logger, _ := ctx.Value(ctxKey{}).(*Logger)
+	return logger
+}
+
+// noopLogger is handed out by G when ctx carries no Logger, so callers such
+// as log.G(ctx).Debugf(...) never need a nil check.
+var noopLogger = NewLogger(nil)
+
+// G returns the Logger attached to ctx, falling back to a no-op Logger when
+// none was set.
+func G(ctx context.Context) *Logger {
+	if logger := FromContext(ctx); logger != nil {
+		return logger
+	}
+	return noopLogger
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2022 Acorn Labs, Inc; All rights reserved.
+// Copyright 2022 Unikraft GmbH; All rights reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+package log
+
+import (
+	"testing"
+	"time"
+)
+
+func fixedEntry() *Entry {
+	return &Entry{
+		Time:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Level:   InfoLevel,
+		Message: "hello world",
+		Fields: map[string]any{
+			"a": "x",
+			"b": 2,
+		},
+	}
+}
+
+func TestTextFormatter(t *testing.T) {
+	f := &TextFormatter{Timestamps: TimestampRFC3339}
+
+	out, err := f.Format(fixedEntry())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `2024-01-02T03:04:05Z INFO    msg="hello world" a=x b=2` + "\n"
+	if string(out) != want {
+		t.Fatalf("got %q, want %q", string(out), want)
+	}
+}
+
+func TestJSONFormatter(t *testing.T) {
+	f := &JSONFormatter{}
+
+	out, err := f.Format(fixedEntry())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `{"a":"x","b":2,"level":"info","msg":"hello world","time":"2024-01-02T03:04:05Z"}` + "\n"
+	if string(out) != want {
+		t.Fatalf("got %q, want %q", string(out), want)
+	}
+}
+
+func TestLogfmtFormatter(t *testing.T) {
+	f := &LogfmtFormatter{}
+
+	out, err := f.Format(fixedEntry())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `ts=2024-01-02T03:04:05Z level=info msg="hello world" a=x b=2` + "\n"
+	if string(out) != want {
+		t.Fatalf("got %q, want %q", string(out), want)
+	}
+}
+
+func TestNoneFormatter(t *testing.T) {
+	f := &NoneFormatter{}
+
+	out, err := f.Format(fixedEntry())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "hello world\n"
+	if string(out) != want {
+		t.Fatalf("got %q, want %q", string(out), want)
+	}
+}
+
+func TestNewFormatterUnknown(t *testing.T) {
+	if _, err := NewFormatter("nope", nil, TimestampNone, false); err == nil {
+		t.Fatal("expected an error for an unknown log type")
+	}
+}